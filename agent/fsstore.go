@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/web3-storage/go-ucanto/core/delegation"
+	"github.com/web3-storage/go-ucanto/did"
+	"github.com/web3-storage/go-ucanto/principal"
+	"github.com/web3-storage/go-ucanto/principal/ed25519/signer"
+	"github.com/web3-storage/go-ucanto/ucan"
+)
+
+// FSStore is a Store backed by a JSON config file and a directory of
+// CAR-encoded delegations, rooted at $XDG_CONFIG_HOME/w3up by default.
+type FSStore struct {
+	dir string
+}
+
+type fsConfig struct {
+	PrivateKey   string `json:"privateKey"`
+	CurrentSpace string `json:"currentSpace,omitempty"`
+}
+
+// NewFSStore opens the filesystem store rooted at dir, creating it (and a
+// fresh agent key) on first use. If dir is empty, $XDG_CONFIG_HOME/w3up is
+// used, falling back to $HOME/.config/w3up.
+func NewFSStore(dir string) (*FSStore, error) {
+	if dir == "" {
+		d, err := defaultConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = d
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "proofs"), 0o700); err != nil {
+		return nil, fmt.Errorf("creating store directory: %w", err)
+	}
+
+	s := &FSStore{dir: dir}
+	if _, err := os.Stat(s.configPath()); os.IsNotExist(err) {
+		if err := s.init(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func defaultConfigDir() (string, error) {
+	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
+		return filepath.Join(d, "w3up"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "w3up"), nil
+}
+
+func (s *FSStore) configPath() string {
+	return filepath.Join(s.dir, "config.json")
+}
+
+func (s *FSStore) proofsDir() string {
+	return filepath.Join(s.dir, "proofs")
+}
+
+// init generates a fresh agent signing key and writes the initial config.
+func (s *FSStore) init() error {
+	sg, err := signer.Generate()
+	if err != nil {
+		return fmt.Errorf("generating agent key: %w", err)
+	}
+	return s.writeConfig(fsConfig{PrivateKey: signer.Format(sg)})
+}
+
+func (s *FSStore) readConfig() (fsConfig, error) {
+	var cfg fsConfig
+	b, err := os.ReadFile(s.configPath())
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", s.configPath(), err)
+	}
+	return cfg, nil
+}
+
+func (s *FSStore) writeConfig(cfg fsConfig) error {
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.configPath(), b, 0o600)
+}
+
+func (s *FSStore) Signer() (principal.Signer, error) {
+	cfg, err := s.readConfig()
+	if err != nil {
+		return nil, err
+	}
+	return signer.Parse(cfg.PrivateKey)
+}
+
+func (s *FSStore) CurrentSpace() (did.DID, error) {
+	cfg, err := s.readConfig()
+	if err != nil {
+		return did.DID{}, err
+	}
+	if cfg.CurrentSpace == "" {
+		return did.DID{}, fmt.Errorf("no current space, run `w3 space use <did>` or `w3 space create` first")
+	}
+	return did.Parse(cfg.CurrentSpace)
+}
+
+func (s *FSStore) UseSpace(space did.DID) error {
+	cfg, err := s.readConfig()
+	if err != nil {
+		return err
+	}
+	cfg.CurrentSpace = space.String()
+	return s.writeConfig(cfg)
+}
+
+func (s *FSStore) Spaces() ([]did.DID, error) {
+	proofs, err := s.allProofs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]struct{}{}
+	var spaces []did.DID
+	for _, d := range proofs {
+		for _, c := range d.Capabilities() {
+			if _, ok := seen[c.With()]; ok {
+				continue
+			}
+			sp, err := did.Parse(c.With())
+			if err != nil {
+				continue
+			}
+			seen[c.With()] = struct{}{}
+			spaces = append(spaces, sp)
+		}
+	}
+	return spaces, nil
+}
+
+func (s *FSStore) AddProof(d delegation.Delegation) error {
+	r, err := delegation.Archive(d)
+	if err != nil {
+		return fmt.Errorf("archiving delegation: %w", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.proofsDir(), d.Link().String()+".car")
+	return os.WriteFile(path, b, 0o600)
+}
+
+func (s *FSStore) ProofsFor(space did.DID, ability ucan.Ability) ([]delegation.Delegation, error) {
+	all, err := s.allProofs()
+	if err != nil {
+		return nil, err
+	}
+
+	var proofs []delegation.Delegation
+	for _, d := range all {
+		for _, c := range d.Capabilities() {
+			if c.With() != space.String() {
+				continue
+			}
+			if c.Can() != string(ability) && c.Can() != "*" {
+				continue
+			}
+			proofs = append(proofs, d)
+			break
+		}
+	}
+	return proofs, nil
+}
+
+func (s *FSStore) allProofs() ([]delegation.Delegation, error) {
+	entries, err := os.ReadDir(s.proofsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	var proofs []delegation.Delegation
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(s.proofsDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		d, err := delegation.Extract(b)
+		if err != nil {
+			continue
+		}
+		proofs = append(proofs, d)
+	}
+	return proofs, nil
+}
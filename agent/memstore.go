@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/web3-storage/go-ucanto/core/delegation"
+	"github.com/web3-storage/go-ucanto/did"
+	"github.com/web3-storage/go-ucanto/principal"
+	"github.com/web3-storage/go-ucanto/principal/ed25519/signer"
+	"github.com/web3-storage/go-ucanto/ucan"
+)
+
+// MemStore is an in-memory Store. It's useful in tests, where persisting
+// agent state to disk isn't wanted.
+type MemStore struct {
+	mu         sync.Mutex
+	signer     principal.Signer
+	current    did.DID
+	hasCurrent bool
+	proofs     []delegation.Delegation
+}
+
+// NewMemStore returns a MemStore seeded with a freshly generated agent
+// signing key.
+func NewMemStore() (*MemStore, error) {
+	s, err := signer.Generate()
+	if err != nil {
+		return nil, err
+	}
+	return &MemStore{signer: s}, nil
+}
+
+func (m *MemStore) Signer() (principal.Signer, error) {
+	return m.signer, nil
+}
+
+func (m *MemStore) CurrentSpace() (did.DID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.hasCurrent {
+		return did.DID{}, fmt.Errorf("no current space, run `w3 space use <did>` or `w3 space create` first")
+	}
+	return m.current, nil
+}
+
+func (m *MemStore) UseSpace(space did.DID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = space
+	m.hasCurrent = true
+	return nil
+}
+
+func (m *MemStore) Spaces() ([]did.DID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := map[string]struct{}{}
+	var spaces []did.DID
+	for _, d := range m.proofs {
+		for _, c := range d.Capabilities() {
+			if _, ok := seen[c.With()]; ok {
+				continue
+			}
+			sp, err := did.Parse(c.With())
+			if err != nil {
+				continue
+			}
+			seen[c.With()] = struct{}{}
+			spaces = append(spaces, sp)
+		}
+	}
+	return spaces, nil
+}
+
+func (m *MemStore) AddProof(d delegation.Delegation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.proofs = append(m.proofs, d)
+	return nil
+}
+
+func (m *MemStore) ProofsFor(space did.DID, ability ucan.Ability) ([]delegation.Delegation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var proofs []delegation.Delegation
+	for _, d := range m.proofs {
+		for _, c := range d.Capabilities() {
+			if c.With() != space.String() {
+				continue
+			}
+			if c.Can() != string(ability) && c.Can() != "*" {
+				continue
+			}
+			proofs = append(proofs, d)
+			break
+		}
+	}
+	return proofs, nil
+}
@@ -0,0 +1,39 @@
+// Package agent manages the signing key, current space, and delegation
+// proofs an agent needs to invoke w3up capabilities, so commands don't
+// have to read them from ad-hoc environment variables and flags on every
+// call.
+package agent
+
+import (
+	"github.com/web3-storage/go-ucanto/core/delegation"
+	"github.com/web3-storage/go-ucanto/did"
+	"github.com/web3-storage/go-ucanto/principal"
+	"github.com/web3-storage/go-ucanto/ucan"
+)
+
+// Store persists an agent's signing key, current space, and delegation
+// proofs across invocations.
+type Store interface {
+	// Signer returns the agent's signing key.
+	Signer() (principal.Signer, error)
+
+	// CurrentSpace returns the DID of the space commands should operate
+	// on when none is given explicitly.
+	CurrentSpace() (did.DID, error)
+
+	// UseSpace sets the DID of the space commands should operate on by
+	// default.
+	UseSpace(did.DID) error
+
+	// Spaces returns the DID of every space the store holds a proof
+	// for.
+	Spaces() ([]did.DID, error)
+
+	// AddProof stores a delegation so it can later be returned by
+	// ProofsFor.
+	AddProof(delegation.Delegation) error
+
+	// ProofsFor returns the delegations that grant the agent ability on
+	// space.
+	ProofsFor(space did.DID, ability ucan.Ability) ([]delegation.Delegation, error)
+}
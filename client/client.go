@@ -0,0 +1,67 @@
+// Package client wraps go-ucanto's client with an asynchronous execution
+// mode for capabilities a service may queue rather than complete inline.
+package client
+
+import (
+	"context"
+	"fmt"
+
+	ucanclient "github.com/web3-storage/go-ucanto/client"
+	"github.com/web3-storage/go-ucanto/core/invocation"
+
+	w3receipt "github.com/web3-storage/go-w3up/receipt"
+)
+
+// ExecuteAsync submits invs to conn exactly like ucanto's client.Execute.
+// For as long as any invocation's receipt isn't yet present in the
+// response, it re-submits the full invocation set with w3receipt.Poller's
+// backoff until every receipt has arrived, opts.Timeout elapses, or ctx
+// is done. Use it for capabilities that may queue work (e.g. upload/add
+// on large DAGs) instead of completing synchronously.
+//
+// Re-submitting invs (rather than only the ones still pending) costs
+// nothing extra: UCAN invocations are content-addressed, so a service
+// that has already executed one just returns its cached receipt. It
+// also keeps every poll's response self-contained, covering every
+// invocation resolved so far rather than only the most recent subset —
+// narrowing resubmission to the pending subset meant a later response
+// could replace an earlier one and drop receipts it had already
+// returned.
+func ExecuteAsync(ctx context.Context, invs []invocation.Invocation, conn ucanclient.Connection, opts w3receipt.Options) (ucanclient.Response, error) {
+	resp, err := ucanclient.Execute(invs, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pendingInvocations(invs, resp)) == 0 {
+		return resp, nil
+	}
+
+	poller := w3receipt.Poller{Options: opts}
+	err = poller.Wait(ctx, func() (bool, error) {
+		r, err := ucanclient.Execute(invs, conn)
+		if err != nil {
+			return false, err
+		}
+
+		resp = r
+		return len(pendingInvocations(invs, r)) == 0, nil
+	})
+	if err != nil {
+		return resp, fmt.Errorf("waiting for receipts: %w", err)
+	}
+
+	return resp, nil
+}
+
+// pendingInvocations returns the invocations in invs whose receipt isn't
+// yet present in resp.
+func pendingInvocations(invs []invocation.Invocation, resp ucanclient.Response) []invocation.Invocation {
+	var pending []invocation.Invocation
+	for _, inv := range invs {
+		if _, ok := resp.Get(inv.Link()); !ok {
+			pending = append(pending, inv)
+		}
+	}
+	return pending
+}
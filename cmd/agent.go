@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/web3-storage/go-ucanto/core/delegation"
+	"github.com/web3-storage/go-ucanto/did"
+	"github.com/web3-storage/go-ucanto/ucan"
+	"github.com/web3-storage/go-w3up/agent"
+)
+
+func mustGetStore() agent.Store {
+	s, err := agent.NewFSStore("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return s
+}
+
+// resolveSpace returns the space a command should operate on: the
+// --space flag value if one was given, otherwise the store's current
+// space.
+func resolveSpace(store agent.Store, flag string) (did.DID, error) {
+	if flag != "" {
+		return did.Parse(flag)
+	}
+	return store.CurrentSpace()
+}
+
+// resolveProofs returns the delegation from the --proof flag if one was
+// given, otherwise every proof the store holds granting any of abilities
+// on space.
+func resolveProofs(store agent.Store, space did.DID, proofFlag string, abilities ...ucan.Ability) ([]delegation.Delegation, error) {
+	if proofFlag != "" {
+		b, err := os.ReadFile(proofFlag)
+		if err != nil {
+			return nil, err
+		}
+		d, err := delegation.Extract(b)
+		if err != nil {
+			return nil, err
+		}
+		return []delegation.Delegation{d}, nil
+	}
+
+	var proofs []delegation.Delegation
+	for _, ability := range abilities {
+		ps, err := store.ProofsFor(space, ability)
+		if err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, ps...)
+	}
+	return proofs, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/urfave/cli/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/web3-storage/go-w3up/observability"
+)
+
+// observer records tracing/metrics for every invocation the CLI makes. It
+// is always set (see setupObservability), and is a no-op until one of
+// --otlp-endpoint/--metrics-addr configures a real exporter.
+var observer *observability.Observer
+
+var observabilityFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "otlp-endpoint",
+		Value: "",
+		Usage: "OTLP/HTTP endpoint to export invocation traces to.",
+	},
+	&cli.StringFlag{
+		Name:  "metrics-addr",
+		Value: "",
+		Usage: "Address to serve Prometheus metrics on, e.g. :9464.",
+	},
+}
+
+// setupObservability wires --otlp-endpoint/--metrics-addr into the global
+// OpenTelemetry providers and initializes observer from them, before any
+// command runs.
+func setupObservability(cCtx *cli.Context) error {
+	var opts []observability.Option
+
+	if endpoint := cCtx.String("otlp-endpoint"); endpoint != "" {
+		exp, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpoint(endpoint))
+		if err != nil {
+			return fmt.Errorf("setting up OTLP exporter: %w", err)
+		}
+		tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp))
+		otel.SetTracerProvider(tp)
+		opts = append(opts, observability.WithTracerProvider(tp))
+	}
+
+	if addr := cCtx.String("metrics-addr"); addr != "" {
+		exp, err := otelprometheus.New()
+		if err != nil {
+			return fmt.Errorf("setting up Prometheus exporter: %w", err)
+		}
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(exp))
+		otel.SetMeterProvider(mp)
+		opts = append(opts, observability.WithMeterProvider(mp))
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Printf("metrics server on %s: %v", addr, err)
+			}
+		}()
+	}
+
+	observer = observability.New(opts...)
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+type outputMode string
+
+const (
+	outputText   outputMode = "text"
+	outputJSON   outputMode = "json"
+	outputNDJSON outputMode = "ndjson"
+)
+
+// globalOutput is set from --output in setupOutput before any command
+// runs.
+var globalOutput = outputText
+
+var outputFlag = &cli.StringFlag{
+	Name:  "output",
+	Value: string(outputText),
+	Usage: "Output format: text, json, or ndjson.",
+}
+
+func setupOutput(cCtx *cli.Context) error {
+	switch m := outputMode(cCtx.String("output")); m {
+	case outputText, outputJSON, outputNDJSON:
+		globalOutput = m
+		return nil
+	default:
+		return fmt.Errorf("unknown --output %q, want text, json, or ndjson", m)
+	}
+}
+
+// printRecord prints v as a single line of JSON in json/ndjson mode, or
+// calls text in the default text mode.
+func printRecord(v any, text func()) error {
+	if globalOutput == outputText {
+		text()
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// printRecords prints records as one JSON object per line in ndjson
+// mode, a single JSON array in json mode, or line-by-line via text in
+// the default text mode.
+func printRecords[T any](records []T, text func(i int, v T)) error {
+	switch globalOutput {
+	case outputText:
+		for i, v := range records {
+			text(i, v)
+		}
+		return nil
+	case outputNDJSON:
+		for _, v := range records {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		}
+		return nil
+	default:
+		b, err := json.Marshal(records)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+}
+
+// errorRecord is the stable JSON shape errors are marshaled to in
+// structured output modes.
+type errorRecord struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message,omitempty"`
+	Cause   string `json:"cause,omitempty"`
+}
+
+// namedError is implemented by receipt failure types (see
+// capability.Failure) that carry a stable name in addition to a message.
+type namedError interface {
+	error
+	Name() string
+}
+
+// causedError is implemented by receipt failure types that wrap an
+// underlying cause.
+type causedError interface {
+	Cause() error
+}
+
+// withOutputErrors wraps a command Action so that, in json/ndjson mode,
+// an error it returns is printed as {"error":...} to stderr and the
+// process exits non-zero, instead of urfave/cli's default "Error: ..."
+// text.
+func withOutputErrors(action cli.ActionFunc) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		err := action(cCtx)
+		if err == nil || globalOutput == outputText {
+			return err
+		}
+
+		detail := errorDetail{Message: err.Error()}
+		if ne, ok := err.(namedError); ok {
+			detail.Name = ne.Name()
+		}
+		if ce, ok := err.(causedError); ok && ce.Cause() != nil {
+			detail.Cause = ce.Cause().Error()
+		}
+
+		b, _ := json.Marshal(errorRecord{Error: detail})
+		fmt.Fprintln(os.Stderr, string(b))
+		return cli.Exit("", 1)
+	}
+}
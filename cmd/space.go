@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"github.com/web3-storage/go-ucanto/core/delegation"
+	"github.com/web3-storage/go-ucanto/did"
+	"github.com/web3-storage/go-ucanto/principal/ed25519/signer"
+	"github.com/web3-storage/go-ucanto/ucan"
+	"github.com/web3-storage/go-w3up/capability"
+)
+
+var spaceCommand = &cli.Command{
+	Name:  "space",
+	Usage: "Manage spaces known to the current agent.",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "create",
+			Usage:  "Create a new space, self-delegate access to it, and make it current.",
+			Action: withOutputErrors(spaceCreate),
+		},
+		{
+			Name:      "use",
+			Usage:     "Set the space commands operate on by default.",
+			ArgsUsage: "<did>",
+			Action:    withOutputErrors(spaceUse),
+		},
+		{
+			Name:      "add",
+			Usage:     "Add a delegation proof for a space.",
+			ArgsUsage: "<proof.car>",
+			Action:    withOutputErrors(spaceAdd),
+		},
+	},
+}
+
+func spaceCreate(cCtx *cli.Context) error {
+	store := mustGetStore()
+	agentSigner, err := store.Signer()
+	if err != nil {
+		return err
+	}
+
+	spaceSigner, err := signer.Generate()
+	if err != nil {
+		return fmt.Errorf("generating space key: %w", err)
+	}
+	space := spaceSigner.DID()
+
+	cap := ucan.NewCapability(
+		"*",
+		space.String(),
+		ucan.MapBuilder(&capability.AnyCaveat{}),
+	)
+
+	proof, err := delegation.Delegate(
+		spaceSigner,
+		agentSigner,
+		cap,
+	)
+	if err != nil {
+		return fmt.Errorf("self-delegating space access: %w", err)
+	}
+
+	if err := store.AddProof(proof); err != nil {
+		return err
+	}
+	if err := store.UseSpace(space); err != nil {
+		return err
+	}
+
+	return printRecord(spaceRecord{DID: space.String()}, func() {
+		fmt.Println(space)
+	})
+}
+
+type spaceRecord struct {
+	DID string `json:"did"`
+}
+
+func spaceUse(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 1 {
+		return fmt.Errorf("usage: w3 space use <did>")
+	}
+
+	space, err := did.Parse(cCtx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return mustGetStore().UseSpace(space)
+}
+
+func spaceAdd(cCtx *cli.Context) error {
+	if cCtx.Args().Len() != 1 {
+		return fmt.Errorf("usage: w3 space add <proof.car>")
+	}
+
+	b, err := os.ReadFile(cCtx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	proof, err := delegation.Extract(b)
+	if err != nil {
+		return err
+	}
+
+	return mustGetStore().AddProof(proof)
+}
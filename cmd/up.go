@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/web3-storage/go-w3up/uploader"
+)
+
+const defaultUploadTimeout = 5 * time.Minute
+
+var upCommand = &cli.Command{
+	Name:      "up",
+	Aliases:   []string{"upload"},
+	Usage:     "Upload a single file to the current space; directories and multiple paths are not supported.",
+	ArgsUsage: "<path>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "space",
+			Value: "",
+			Usage: "DID of space to upload to.",
+		},
+		&cli.StringFlag{
+			Name:  "proof",
+			Value: "",
+			Usage: "Path to file containing UCAN proof(s) for the operation.",
+		},
+		&cli.Int64Flag{
+			Name:  "shard-size",
+			Value: uploader.DefaultShardSize,
+			Usage: "Block payload size in bytes at which a CAR shard is closed and a new one started.",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Value: uploader.DefaultConcurrency,
+			Usage: "Number of store/add invocations to run in parallel.",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Value: false,
+			Usage: "Poll for a receipt instead of requiring one in the immediate response.",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Value: defaultUploadTimeout,
+			Usage: "How long --wait polls for a receipt before giving up.",
+		},
+	},
+	Action: withOutputErrors(up),
+}
+
+func up(cCtx *cli.Context) error {
+	if cCtx.Args().Len() == 0 {
+		return fmt.Errorf("missing path(s) to upload")
+	}
+
+	store := mustGetStore()
+	signer, err := store.Signer()
+	if err != nil {
+		return err
+	}
+	conn := mustGetConnection()
+
+	space, err := resolveSpace(store, cCtx.String("space"))
+	if err != nil {
+		return err
+	}
+
+	// Upload issues both store/add and upload/add invocations, so it
+	// needs proofs covering whichever of those abilities the space's
+	// delegations split across.
+	proofs, err := resolveProofs(store, space, cCtx.String("proof"), "upload/add", "store/add")
+	if err != nil {
+		return err
+	}
+
+	res, err := uploader.Upload(signer, conn, space, proofs, cCtx.Args().Slice(), uploader.Options{
+		ShardSize:   cCtx.Int64("shard-size"),
+		Concurrency: cCtx.Int("concurrency"),
+		Observer:    observer,
+		Wait:        cCtx.Bool("wait"),
+		Timeout:     cCtx.Duration("timeout"),
+	})
+	if err != nil {
+		return err
+	}
+
+	record := upRecord{Root: fmt.Sprint(res.Root)}
+	for _, s := range res.Shards {
+		record.Shards = append(record.Shards, fmt.Sprint(s))
+	}
+
+	return printRecord(record, func() {
+		fmt.Println(res.Root)
+		for _, s := range res.Shards {
+			fmt.Printf("\t%s\n", s)
+		}
+	})
+}
+
+type upRecord struct {
+	Root   string   `json:"root"`
+	Shards []string `json:"shards,omitempty"`
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/url"
@@ -12,23 +13,29 @@ import (
 	"github.com/web3-storage/go-ucanto/core/invocation"
 	"github.com/web3-storage/go-ucanto/core/receipt"
 	"github.com/web3-storage/go-ucanto/did"
-	"github.com/web3-storage/go-ucanto/principal"
-	"github.com/web3-storage/go-ucanto/principal/ed25519/signer"
 	"github.com/web3-storage/go-ucanto/transport/car"
 	"github.com/web3-storage/go-ucanto/transport/http"
 	"github.com/web3-storage/go-ucanto/ucan"
 	"github.com/web3-storage/go-w3up/capability"
+	"github.com/web3-storage/go-w3up/observability"
 )
 
 func main() {
 	app := &cli.App{
 		Name:  "w3",
 		Usage: "interact with the web3.storage API",
+		Flags: append([]cli.Flag{outputFlag}, observabilityFlags...),
+		Before: func(cCtx *cli.Context) error {
+			if err := setupOutput(cCtx); err != nil {
+				return err
+			}
+			return setupObservability(cCtx)
+		},
 		Commands: []*cli.Command{
 			{
 				Name:   "whoami",
 				Usage:  "Print information about the current agent.",
-				Action: whoami,
+				Action: withOutputErrors(whoami),
 			},
 			{
 				Name:    "ls",
@@ -51,8 +58,10 @@ func main() {
 						Usage: "Display shard CID(s) for each upload root.",
 					},
 				},
-				Action: ls,
+				Action: withOutputErrors(ls),
 			},
+			upCommand,
+			spaceCommand,
 		},
 	}
 
@@ -61,26 +70,34 @@ func main() {
 	}
 }
 
+type whoamiRecord struct {
+	DID string `json:"did"`
+}
+
 func whoami(cCtx *cli.Context) error {
-	s := mustGetSignerFromEnv()
-	fmt.Println(s.DID())
-	return nil
+	s, err := mustGetStore().Signer()
+	if err != nil {
+		return err
+	}
+	return printRecord(whoamiRecord{DID: s.DID().String()}, func() {
+		fmt.Println(s.DID())
+	})
 }
 
 func ls(cCtx *cli.Context) error {
-	signer := mustGetSignerFromEnv()
-	conn := mustGetConnection()
-	space, err := did.Parse(cCtx.String("space"))
+	store := mustGetStore()
+	signer, err := store.Signer()
 	if err != nil {
 		return err
 	}
+	conn := mustGetConnection()
 
-	bytes, err := os.ReadFile(cCtx.String("proof"))
+	space, err := resolveSpace(store, cCtx.String("space"))
 	if err != nil {
 		return err
 	}
 
-	proof, err := delegation.Extract(bytes)
+	proofs, err := resolveProofs(store, space, cCtx.String("proof"), "upload/list")
 	if err != nil {
 		return err
 	}
@@ -95,64 +112,74 @@ func ls(cCtx *cli.Context) error {
 		signer,
 		conn.ID(),
 		cap,
-		delegation.WithProofs([]delegation.Delegation{proof}),
+		delegation.WithProofs(proofs),
 	)
 	if err != nil {
 		return err
 	}
 
+	_, tracked := observer.Start(context.Background(), "upload/list", space.String(), inv.Link())
+
 	// send the invocation(s) to the service
 	resp, err := client.Execute([]invocation.Invocation{inv}, conn)
 	if err != nil {
+		tracked.Fail(err)
 		return err
 	}
 
 	reader, err := receipt.NewReceiptReader[*capability.UploadListSuccess, *capability.UploadListFailure](capability.UploadSchema)
 	if err != nil {
+		tracked.Fail(err)
 		return err
 	}
 
 	// get the receipt link for the invocation from the response
 	rcptlnk, ok := resp.Get(inv.Link())
 	if err != nil {
+		tracked.Fail(err)
 		return err
 	}
 	if !ok {
-		return fmt.Errorf("receipt not found: %s", inv.Link())
+		err := fmt.Errorf("receipt not found: %s", inv.Link())
+		tracked.Fail(err)
+		return err
 	}
 
 	// read the receipt for the invocation from the response
 	rcpt, err := reader.Read(rcptlnk, resp.Blocks())
 	if err != nil {
+		tracked.Fail(err)
 		return err
 	}
 
-	if rcpt.Out().Error() != nil {
-		log.Fatalf("%+v\n", rcpt.Out().Error())
+	if err := rcpt.Out().Error(); err != nil {
+		tracked.End(rcptlnk, err)
+		return err
 	}
+	tracked.End(rcptlnk, nil)
 
-	for _, r := range rcpt.Out().Ok().Results {
-		fmt.Printf("%s\n", r.Root)
-		if cCtx.Bool("shards") {
+	showShards := cCtx.Bool("shards")
+	records := make([]lsRecord, len(rcpt.Out().Ok().Results))
+	for i, r := range rcpt.Out().Ok().Results {
+		records[i] = lsRecord{Root: fmt.Sprint(r.Root)}
+		if showShards {
 			for _, s := range r.Shards {
-				fmt.Printf("\t%s\n", s)
+				records[i].Shards = append(records[i].Shards, fmt.Sprint(s))
 			}
 		}
 	}
 
-	return nil
+	return printRecords(records, func(i int, r lsRecord) {
+		fmt.Printf("%s\n", r.Root)
+		for _, s := range r.Shards {
+			fmt.Printf("\t%s\n", s)
+		}
+	})
 }
 
-func mustGetSignerFromEnv() principal.Signer {
-	str := os.Getenv("W3UP_PRIVATE_KEY")
-	if str == "" {
-		panic("missing W3UP_PRIVATE_KEY env var")
-	}
-	s, err := signer.Parse(str)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return s
+type lsRecord struct {
+	Root   string   `json:"root"`
+	Shards []string `json:"shards,omitempty"`
 }
 
 func mustGetConnection() client.Connection {
@@ -176,5 +203,10 @@ func mustGetConnection() client.Connection {
 		log.Fatal(err)
 	}
 
+	conn, err = observability.WrapConnection(conn, observer)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	return conn
 }
@@ -0,0 +1,50 @@
+package observability
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/web3-storage/go-ucanto/client"
+	"github.com/web3-storage/go-ucanto/transport"
+)
+
+// WrapConnection returns a Connection identical to conn except that every
+// request it sends and response it receives passes through o's
+// bytes_in/bytes_out counters. Use it once, when a Connection is built,
+// so every invocation path that executes against it is measured without
+// needing to call o.RecordBytes at each call site.
+func WrapConnection(conn client.Connection, o *Observer) (client.Connection, error) {
+	return client.NewConnection(conn.ID(), conn.Codec(), wrapChannel(conn.Channel(), o))
+}
+
+// instrumentedChannel records the size of a request body and its
+// response body on every round trip, then delegates to ch.
+type instrumentedChannel struct {
+	ch transport.Channel
+	o  *Observer
+}
+
+func wrapChannel(ch transport.Channel, o *Observer) transport.Channel {
+	return &instrumentedChannel{ch: ch, o: o}
+}
+
+func (c *instrumentedChannel) Request(req transport.HTTPRequest) (transport.HTTPResponse, error) {
+	reqBody, err := io.ReadAll(req.Body())
+	if err != nil {
+		return nil, err
+	}
+	c.o.RecordBytes(len(reqBody), 0)
+
+	resp, err := c.ch.Request(transport.NewHTTPRequest(bytes.NewReader(reqBody), req.Headers()))
+	if err != nil {
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body())
+	if err != nil {
+		return resp, err
+	}
+	c.o.RecordBytes(0, len(respBody))
+
+	return transport.NewHTTPResponse(resp.Status(), bytes.NewReader(respBody), resp.Headers()), nil
+}
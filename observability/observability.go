@@ -0,0 +1,163 @@
+// Package observability instruments ucanto invocations with OpenTelemetry
+// tracing and Prometheus metrics, so operators running w3 in batch jobs
+// can see what it's doing without reading logs.
+package observability
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/web3-storage/go-w3up/observability"
+
+// Option configures an Observer.
+type Option func(*config)
+
+type config struct {
+	tp trace.TracerProvider
+	mp metric.MeterProvider
+}
+
+// WithTracerProvider sets the TracerProvider an Observer uses to start
+// spans. The global provider is used if this option isn't given.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tp = tp }
+}
+
+// WithMeterProvider sets the MeterProvider an Observer uses to record
+// metrics. The global provider is used if this option isn't given.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) { c.mp = mp }
+}
+
+// Observer records a span and a set of counters/histograms around every
+// UCAN invocation it's asked to track.
+type Observer struct {
+	tracer trace.Tracer
+
+	invocations metric.Int64Counter
+	duration    metric.Float64Histogram
+	bytesIn     metric.Int64Counter
+	bytesOut    metric.Int64Counter
+}
+
+// New builds an Observer. With no options, it reports to the global
+// OpenTelemetry tracer/meter providers, so it's a no-op until those are
+// configured (e.g. by an OTLP exporter).
+func New(opts ...Option) *Observer {
+	cfg := config{tp: otel.GetTracerProvider(), mp: otel.GetMeterProvider()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := cfg.mp.Meter(instrumentationName)
+	invocations, _ := meter.Int64Counter(
+		"invocations_total",
+		metric.WithDescription("UCAN invocations executed, by capability and outcome"),
+	)
+	duration, _ := meter.Float64Histogram(
+		"invocation_duration_seconds",
+		metric.WithDescription("Time to execute a UCAN invocation and read its receipt"),
+	)
+	bytesIn, _ := meter.Int64Counter(
+		"bytes_in",
+		metric.WithDescription("Bytes read from the service over the transport"),
+	)
+	bytesOut, _ := meter.Int64Counter(
+		"bytes_out",
+		metric.WithDescription("Bytes written to the service over the transport"),
+	)
+
+	return &Observer{
+		tracer:      cfg.tp.Tracer(instrumentationName),
+		invocations: invocations,
+		duration:    duration,
+		bytesIn:     bytesIn,
+		bytesOut:    bytesOut,
+	}
+}
+
+// Invocation tracks a single UCAN invocation, from the moment it's about
+// to be executed to the moment its receipt has been read.
+type Invocation struct {
+	o       *Observer
+	span    trace.Span
+	can     string
+	started time.Time
+}
+
+// Start begins tracking an invocation, returning a context carrying the
+// new span and the Invocation used to finish it. invocationLink is
+// typically the result of invocation.Invocation.Link().
+func (o *Observer) Start(ctx context.Context, can, resource string, invocationLink fmt.Stringer) (context.Context, *Invocation) {
+	ctx, span := o.tracer.Start(ctx, "ucanto.invoke."+can,
+		trace.WithAttributes(
+			attribute.String("ucan.can", can),
+			attribute.String("ucan.resource", resource),
+			attribute.String("ucan.invocation_cid", invocationLink.String()),
+		),
+	)
+	return ctx, &Invocation{o: o, span: span, can: can, started: time.Now()}
+}
+
+// End finishes tracking the invocation. responseLink is the receipt's
+// link and receiptErr is the error (if any) the receipt carried in its
+// Out; a non-nil receiptErr marks the span as failed even though the
+// transport call itself succeeded.
+func (i *Invocation) End(responseLink fmt.Stringer, receiptErr error) {
+	outcome := "ok"
+	if receiptErr != nil {
+		outcome = "error"
+		i.span.SetStatus(codes.Error, receiptErr.Error())
+		i.span.RecordError(receiptErr)
+	}
+	i.span.SetAttributes(attribute.String("ucan.response_cid", responseLink.String()))
+	i.span.End()
+
+	i.o.invocations.Add(context.Background(), 1,
+		metric.WithAttributes(
+			attribute.String("can", i.can),
+			attribute.String("outcome", outcome),
+		),
+	)
+	i.o.duration.Record(context.Background(), time.Since(i.started).Seconds(),
+		metric.WithAttributes(attribute.String("can", i.can)),
+	)
+}
+
+// RecordBytes increments the transport byte counters. It's called from
+// the instrumentedChannel WrapConnection installs, with the size of the
+// request sent and response received for a single HTTP round trip.
+func (o *Observer) RecordBytes(out, in int) {
+	if out > 0 {
+		o.bytesOut.Add(context.Background(), int64(out))
+	}
+	if in > 0 {
+		o.bytesIn.Add(context.Background(), int64(in))
+	}
+}
+
+// Fail finishes tracking the invocation when it could not even be
+// executed (e.g. a transport error), before any receipt was read.
+func (i *Invocation) Fail(err error) {
+	i.span.SetStatus(codes.Error, err.Error())
+	i.span.RecordError(err)
+	i.span.End()
+
+	i.o.invocations.Add(context.Background(), 1,
+		metric.WithAttributes(
+			attribute.String("can", i.can),
+			attribute.String("outcome", "transport_error"),
+		),
+	)
+	i.o.duration.Record(context.Background(), time.Since(i.started).Seconds(),
+		metric.WithAttributes(attribute.String("can", i.can)),
+	)
+}
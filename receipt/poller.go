@@ -0,0 +1,85 @@
+// Package receipt helps wait for the receipt of a UCAN invocation that a
+// service may process asynchronously (queuing the work) instead of
+// completing it before responding.
+package receipt
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultPollInterval is the initial delay between polls when none
+	// is configured.
+	DefaultPollInterval = 500 * time.Millisecond
+
+	// DefaultMaxPollInterval caps the exponential backoff between
+	// polls when no cap is configured.
+	DefaultMaxPollInterval = 10 * time.Second
+)
+
+// Options configures a Poller's backoff.
+type Options struct {
+	// Timeout bounds how long Wait polls for before giving up. Zero
+	// means wait forever (until ctx is done).
+	Timeout time.Duration
+	// PollInterval is the delay before the first retry; it doubles (up
+	// to MaxPollInterval) after every miss.
+	PollInterval time.Duration
+	// MaxPollInterval caps the backoff between polls.
+	MaxPollInterval time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultPollInterval
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = DefaultMaxPollInterval
+	}
+	return o
+}
+
+// Poller retries a lookup with exponential backoff until it reports a
+// terminal result, ctx is done, or it times out.
+type Poller struct {
+	Options Options
+}
+
+// Wait calls lookup repeatedly, backing off between misses, until lookup
+// returns done=true, ctx is cancelled, or the configured timeout elapses.
+func (p Poller) Wait(ctx context.Context, lookup func() (done bool, err error)) error {
+	opts := p.Options.withDefaults()
+	interval := opts.PollInterval
+
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+
+	for {
+		done, err := lookup()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for receipt")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > opts.MaxPollInterval {
+			interval = opts.MaxPollInterval
+		}
+	}
+}
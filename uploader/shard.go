@@ -0,0 +1,273 @@
+package uploader
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ipfs/boxo/blocks"
+	"github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/boxo/chunker"
+	dag "github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/balanced"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/helpers"
+	"github.com/ipfs/go-cid"
+	carv2 "github.com/ipld/go-car/v2"
+	carblockstore "github.com/ipld/go-car/v2/blockstore"
+	"github.com/multiformats/go-multihash"
+)
+
+// Shard is a single CAR file produced while splitting a UnixFS DAG. CID
+// identifies the CAR file's own bytes (a raw-codec sha2-256 CID), which
+// is what store/add and upload/add invocations use as the shard's link.
+type Shard struct {
+	CID  cid.Cid
+	Path string
+	Size int64
+}
+
+// shardWriter is a single CAR file on disk together with the number of
+// block payload bytes written to it so far.
+type shardWriter struct {
+	bs   *carblockstore.ReadWrite
+	path string
+	size int64
+}
+
+func newShardWriter(dir string) (*shardWriter, error) {
+	f, err := os.CreateTemp(dir, "w3up-shard-*.car")
+	if err != nil {
+		return nil, fmt.Errorf("creating shard file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+
+	bs, err := carblockstore.OpenReadWrite(path, nil, carv2.StoreIdentityCIDs(true))
+	if err != nil {
+		return nil, fmt.Errorf("opening shard blockstore: %w", err)
+	}
+	return &shardWriter{bs: bs, path: path}, nil
+}
+
+// finalize closes out the CAR file and returns the Shard identifying it.
+// The reported Size is the CAR file's actual size on disk, not the sum
+// of block payload bytes written to it: the CARv2 pragma/header and the
+// per-block varint+CID framing make the file itself larger than that,
+// and Size is what store/add and the presigned PUT's Content-Length are
+// told to expect.
+func (w *shardWriter) finalize() (Shard, error) {
+	if err := w.bs.Finalize(); err != nil {
+		return Shard{}, fmt.Errorf("finalizing shard: %w", err)
+	}
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return Shard{}, err
+	}
+	shardCID, err := carCID(w.path)
+	if err != nil {
+		return Shard{}, err
+	}
+	return Shard{CID: shardCID, Path: w.path, Size: fi.Size()}, nil
+}
+
+// carCID returns the CID identifying a CAR file's bytes: a raw-codec CID
+// over the sha2-256 of the file, which is what store/add and upload/add
+// use as a shard's link.
+func carCID(path string) (cid.Cid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cid.Undef, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return cid.Undef, err
+	}
+	mh, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return cid.NewCidV1(cid.Raw, mh), nil
+}
+
+// shardSink is a blockstore.Blockstore that fans writes out across one or
+// more shardWriters, closing out the current one and opening a fresh one
+// whenever its block payload reaches sizeLimit. Every block the DAG builder writes —
+// leaf or interior UnixFS node alike — flows through Put/PutMany, so a
+// single large file's DAG can span multiple CAR shards without ever
+// holding the whole DAG in memory.
+type shardSink struct {
+	dir       string
+	sizeLimit int64
+
+	cur    *shardWriter
+	shards []Shard
+}
+
+func newShardSink(dir string, sizeLimit int64) (*shardSink, error) {
+	w, err := newShardWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &shardSink{dir: dir, sizeLimit: sizeLimit, cur: w}, nil
+}
+
+func (s *shardSink) DAGService() dag.DAGService {
+	return dag.NewDAGService(blockServiceFor(s))
+}
+
+func (s *shardSink) DeleteBlock(ctx context.Context, c cid.Cid) error {
+	return s.cur.bs.DeleteBlock(ctx, c)
+}
+
+func (s *shardSink) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	return s.cur.bs.Has(ctx, c)
+}
+
+func (s *shardSink) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	return s.cur.bs.Get(ctx, c)
+}
+
+func (s *shardSink) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	return s.cur.bs.GetSize(ctx, c)
+}
+
+func (s *shardSink) Put(ctx context.Context, b blocks.Block) error {
+	return s.PutMany(ctx, []blocks.Block{b})
+}
+
+func (s *shardSink) PutMany(ctx context.Context, bs []blocks.Block) error {
+	for _, b := range bs {
+		if err := s.cur.bs.Put(ctx, b); err != nil {
+			return err
+		}
+		s.cur.size += int64(len(b.RawData()))
+
+		if s.cur.size >= s.sizeLimit {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *shardSink) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return s.cur.bs.AllKeysChan(ctx)
+}
+
+func (s *shardSink) HashOnRead(enabled bool) {
+	s.cur.bs.HashOnRead(enabled)
+}
+
+// rotate finalizes the current shard and opens a fresh one to receive
+// subsequent blocks.
+func (s *shardSink) rotate() error {
+	sh, err := s.cur.finalize()
+	if err != nil {
+		return err
+	}
+	s.shards = append(s.shards, sh)
+
+	w, err := newShardWriter(s.dir)
+	if err != nil {
+		return err
+	}
+	s.cur = w
+	return nil
+}
+
+// finalize closes out the current shard and returns every shard written,
+// in order. If a rotation happened on the very last block written, cur
+// is a fresh shard that received nothing; skip finalizing it rather than
+// emit a payload-less shard that would still get its own store/add
+// invocation and upload/add entry.
+func (s *shardSink) finalize() ([]Shard, error) {
+	if s.cur.size == 0 && len(s.shards) > 0 {
+		return s.shards, nil
+	}
+	sh, err := s.cur.finalize()
+	if err != nil {
+		return nil, err
+	}
+	return append(s.shards, sh), nil
+}
+
+// dagBuilderParams returns the chunking/layout parameters used when
+// importing a file into a shardSink. A fixed 256KiB chunk size with
+// balanced layout matches the defaults used by the JS w3up client so CIDs
+// produced by either client are interchangeable.
+func dagBuilderParams(ds dag.DAGService) helpers.DagBuilderParams {
+	return helpers.DagBuilderParams{
+		Dagserv:    ds,
+		Maxlinks:   helpers.DefaultLinksPerBlock,
+		CidBuilder: dag.V1CidPrefix(),
+	}
+}
+
+func splitter(r io.Reader) chunker.Splitter {
+	return chunker.NewSizeSplitter(r, 256*1024)
+}
+
+// balancedLayout builds a balanced UnixFS DAG for r using db, returning the
+// root node.
+func balancedLayout(db *helpers.DagBuilderHelper) (dag.Node, error) {
+	return balanced.Layout(db)
+}
+
+// shardPaths streams the contents of paths into a UnixFS DAG, writing it
+// out as one or more CAR shards under dir. A new shard is started once the
+// block payload written to the current one reaches sizeLimit, so a shard's
+// payload can exceed sizeLimit by up to one block, and its CAR file is
+// larger still by the CAR header, per-block framing and index. It returns
+// every shard written and the CID of the DAG root.
+//
+// Only a single, non-directory path is supported right now: wrapping
+// multiple files or a directory tree in a UnixFS directory node isn't
+// implemented yet, so shardPaths rejects that input explicitly rather
+// than silently producing a root that only covers the last path.
+func shardPaths(dir string, paths []string, sizeLimit int64) ([]Shard, cid.Cid, error) {
+	if len(paths) != 1 {
+		return nil, cid.Undef, fmt.Errorf("up: exactly one path is supported, got %d", len(paths))
+	}
+	path := paths[0]
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, cid.Undef, fmt.Errorf("stat %s: %w", path, err)
+	}
+	if fi.IsDir() {
+		return nil, cid.Undef, fmt.Errorf("up: directories are not supported yet: %s", path)
+	}
+
+	sink, err := newShardSink(dir, sizeLimit)
+	if err != nil {
+		return nil, cid.Undef, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, cid.Undef, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	db, err := helpers.NewDagBuilderHelper(dagBuilderParams(sink.DAGService()), splitter(f))
+	if err != nil {
+		return nil, cid.Undef, fmt.Errorf("building DAG for %s: %w", path, err)
+	}
+
+	root, err := balancedLayout(db)
+	if err != nil {
+		return nil, cid.Undef, fmt.Errorf("laying out DAG for %s: %w", path, err)
+	}
+
+	shards, err := sink.finalize()
+	if err != nil {
+		return nil, cid.Undef, err
+	}
+
+	return shards, root.Cid(), nil
+}
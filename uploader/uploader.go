@@ -0,0 +1,312 @@
+// Package uploader builds a UnixFS DAG from a local file, shards it into
+// CAR files under a configurable size threshold, and uploads the shards
+// to a w3up service via store/add and upload/add invocations.
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ipfs/boxo/blockservice"
+	"github.com/ipfs/boxo/blockstore"
+	offline "github.com/ipfs/boxo/exchange/offline"
+	"github.com/ipfs/go-cid"
+	"github.com/web3-storage/go-ucanto/client"
+	"github.com/web3-storage/go-ucanto/core/delegation"
+	"github.com/web3-storage/go-ucanto/core/invocation"
+	"github.com/web3-storage/go-ucanto/core/receipt"
+	"github.com/web3-storage/go-ucanto/did"
+	"github.com/web3-storage/go-ucanto/principal"
+	"github.com/web3-storage/go-ucanto/ucan"
+	"github.com/web3-storage/go-w3up/capability"
+	asyncclient "github.com/web3-storage/go-w3up/client"
+	"github.com/web3-storage/go-w3up/observability"
+	w3receipt "github.com/web3-storage/go-w3up/receipt"
+)
+
+const (
+	// DefaultShardSize is the shard size threshold, in bytes, used when
+	// no size is configured (see Options.ShardSize).
+	DefaultShardSize = 100 * 1024 * 1024 // 100 MiB
+
+	// DefaultConcurrency is the number of store/add invocations issued
+	// in parallel when no concurrency limit is configured.
+	DefaultConcurrency = 4
+)
+
+// Options configures a call to Upload.
+type Options struct {
+	// ShardSize is the block payload size, in bytes, at which a CAR
+	// shard is closed and a new one started. A shard's payload can
+	// exceed it by up to one block, and its CAR file adds header,
+	// framing and index bytes on top.
+	ShardSize int64
+	// Concurrency bounds the number of store/add invocations in flight
+	// at once.
+	Concurrency int
+	// Observer records tracing/metrics for the store/add and upload/add
+	// invocations Upload makes. If nil, an Observer reporting to the
+	// global OpenTelemetry providers is used.
+	Observer *observability.Observer
+	// Wait, if true, polls for a terminal receipt instead of requiring
+	// one in the service's immediate response, for services that queue
+	// store/add or upload/add rather than completing them inline.
+	Wait bool
+	// Timeout bounds how long Upload waits for a receipt when Wait is
+	// true. Zero means wait forever.
+	Timeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.ShardSize <= 0 {
+		o.ShardSize = DefaultShardSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConcurrency
+	}
+	if o.Observer == nil {
+		o.Observer = observability.New()
+	}
+	return o
+}
+
+// Result is the outcome of a successful upload.
+type Result struct {
+	Root   cid.Cid
+	Shards []cid.Cid
+}
+
+// Upload builds a UnixFS DAG for the given path (a single file; see
+// shardPaths), shards it into CAR files under opts.ShardSize, issues a
+// store/add invocation for each shard (up to opts.Concurrency at a
+// time), and finally links the root CID to every shard with a single
+// upload/add invocation.
+func Upload(s principal.Signer, conn client.Connection, space did.DID, proofs []delegation.Delegation, paths []string, opts Options) (Result, error) {
+	opts = opts.withDefaults()
+
+	dir, err := os.MkdirTemp("", "w3up-shards-*")
+	if err != nil {
+		return Result{}, fmt.Errorf("creating shard scratch dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	shards, root, err := shardPaths(dir, paths, opts.ShardSize)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := storeAddShards(s, conn, space, proofs, shards, opts); err != nil {
+		return Result{}, err
+	}
+
+	shardCIDs := make([]cid.Cid, len(shards))
+	for i, sh := range shards {
+		shardCIDs[i] = sh.CID
+	}
+
+	if err := uploadAdd(s, conn, space, proofs, root, shardCIDs, opts); err != nil {
+		return Result{}, err
+	}
+
+	return Result{Root: root, Shards: shardCIDs}, nil
+}
+
+// storeAddShards issues a store/add invocation for each shard, running up
+// to concurrency invocations in parallel.
+func storeAddShards(s principal.Signer, conn client.Connection, space did.DID, proofs []delegation.Delegation, shards []Shard, opts Options) error {
+	sem := make(chan struct{}, opts.Concurrency)
+	errs := make(chan error, len(shards))
+	var wg sync.WaitGroup
+
+	for _, sh := range shards {
+		sh := sh
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs <- storeAddShard(s, conn, space, proofs, sh, opts)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func storeAddShard(s principal.Signer, conn client.Connection, space did.DID, proofs []delegation.Delegation, sh Shard, opts Options) error {
+	o := opts.Observer
+	f, err := os.Open(sh.Path)
+	if err != nil {
+		return fmt.Errorf("opening shard %s: %w", sh.CID, err)
+	}
+	defer f.Close()
+
+	cap := ucan.NewCapability(
+		"store/add",
+		space.String(),
+		ucan.MapBuilder(&capability.StoreAddCaveat{
+			Link: sh.CID,
+			Size: sh.Size,
+		}),
+	)
+
+	inv, err := invocation.Invoke(
+		s,
+		conn.ID(),
+		cap,
+		delegation.WithProofs(proofs),
+	)
+	if err != nil {
+		return fmt.Errorf("invoking store/add for shard %s: %w", sh.CID, err)
+	}
+
+	_, tracked := o.Start(context.Background(), "store/add", space.String(), inv.Link())
+
+	resp, err := execute(context.Background(), []invocation.Invocation{inv}, conn, opts)
+	if err != nil {
+		tracked.Fail(err)
+		return fmt.Errorf("executing store/add for shard %s: %w", sh.CID, err)
+	}
+
+	reader, err := receipt.NewReceiptReader[*capability.StoreAddSuccess, *capability.StoreAddFailure](capability.StoreSchema)
+	if err != nil {
+		tracked.Fail(err)
+		return err
+	}
+
+	rcptlnk, ok := resp.Get(inv.Link())
+	if !ok {
+		err := fmt.Errorf("receipt not found for shard %s: %s", sh.CID, inv.Link())
+		tracked.Fail(err)
+		return err
+	}
+
+	rcpt, err := reader.Read(rcptlnk, resp.Blocks())
+	if err != nil {
+		tracked.Fail(err)
+		return fmt.Errorf("reading store/add receipt for shard %s: %w", sh.CID, err)
+	}
+
+	if err := rcpt.Out().Error(); err != nil {
+		tracked.End(rcptlnk, err)
+		return err
+	}
+	tracked.End(rcptlnk, nil)
+
+	out := rcpt.Out().Ok()
+	if out.Status == capability.StoreStatusUpload {
+		if err := putShard(out.URL, out.Headers, f, sh.Size); err != nil {
+			return fmt.Errorf("uploading shard %s: %w", sh.CID, err)
+		}
+	}
+
+	return nil
+}
+
+// putShard uploads a shard's bytes directly to the presigned URL returned
+// by store/add. It always sends the PUT; storeAddShard calls it only when
+// the receipt's status asks for an upload (otherwise the service already
+// has the shard).
+func putShard(url string, headers map[string]string, r io.Reader, size int64) error {
+	req, err := http.NewRequest(http.MethodPut, url, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status from shard upload: %s", resp.Status)
+	}
+	return nil
+}
+
+func uploadAdd(s principal.Signer, conn client.Connection, space did.DID, proofs []delegation.Delegation, root cid.Cid, shards []cid.Cid, opts Options) error {
+	o := opts.Observer
+	cap := ucan.NewCapability(
+		"upload/add",
+		space.String(),
+		ucan.MapBuilder(&capability.UploadAddCaveat{
+			Root:   root,
+			Shards: shards,
+		}),
+	)
+
+	inv, err := invocation.Invoke(
+		s,
+		conn.ID(),
+		cap,
+		delegation.WithProofs(proofs),
+	)
+	if err != nil {
+		return fmt.Errorf("invoking upload/add: %w", err)
+	}
+
+	_, tracked := o.Start(context.Background(), "upload/add", space.String(), inv.Link())
+
+	resp, err := execute(context.Background(), []invocation.Invocation{inv}, conn, opts)
+	if err != nil {
+		tracked.Fail(err)
+		return fmt.Errorf("executing upload/add: %w", err)
+	}
+
+	reader, err := receipt.NewReceiptReader[*capability.UploadAddSuccess, *capability.UploadAddFailure](capability.UploadSchema)
+	if err != nil {
+		tracked.Fail(err)
+		return err
+	}
+
+	rcptlnk, ok := resp.Get(inv.Link())
+	if !ok {
+		err := fmt.Errorf("receipt not found: %s", inv.Link())
+		tracked.Fail(err)
+		return err
+	}
+
+	rcpt, err := reader.Read(rcptlnk, resp.Blocks())
+	if err != nil {
+		tracked.Fail(err)
+		return fmt.Errorf("reading upload/add receipt: %w", err)
+	}
+
+	if err := rcpt.Out().Error(); err != nil {
+		tracked.End(rcptlnk, err)
+		return err
+	}
+	tracked.End(rcptlnk, nil)
+
+	return nil
+}
+
+func blockServiceFor(bs blockstore.Blockstore) blockservice.BlockService {
+	return blockservice.New(bs, offline.Exchange(bs))
+}
+
+// execute runs invs against conn, polling for a terminal receipt instead
+// of requiring one in the immediate response when opts.Wait is set.
+func execute(ctx context.Context, invs []invocation.Invocation, conn client.Connection, opts Options) (client.Response, error) {
+	if !opts.Wait {
+		return client.Execute(invs, conn)
+	}
+	return asyncclient.ExecuteAsync(ctx, invs, conn, w3receipt.Options{Timeout: opts.Timeout})
+}